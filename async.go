@@ -0,0 +1,158 @@
+package diary
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCloseTimeout bounds how long Close, called through the plain
+// io.Closer interface, waits for a flush to finish. Callers that need more
+// control can type-assert the returned io.WriteCloser to access
+// CloseTimeout directly.
+const defaultCloseTimeout = 5 * time.Second
+
+// dropReportInterval is how often the background goroutine flushes a
+// synthetic log line describing records dropped since the last report.
+const dropReportInterval = time.Second
+
+// NewAsyncWriter returns an io.WriteCloser that decouples formatting from
+// I/O: each Write pushes an already-serialized record onto a buffer of
+// bufSize records, drained by a background goroutine into w. This is aimed
+// at latency-sensitive services where a synchronous write, such as
+// Logger's default l.writer.Write, can stall a request path on a slow disk
+// or remote sink.
+//
+// When the buffer is full, the record is dropped rather than blocking the
+// caller. onDrop, if non-nil, is called with the cumulative number of
+// records dropped so far whenever a drop occurs. The background goroutine
+// additionally flushes a synthetic log line to w roughly once a second
+// when new drops have occurred, so operators watching the log itself
+// notice the loss.
+func NewAsyncWriter(w io.Writer, bufSize int, onDrop func(n int)) io.WriteCloser {
+	a := &asyncWriter{
+		w:      w,
+		ch:     make(chan []byte, bufSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		onDrop: onDrop,
+	}
+
+	go a.run()
+
+	return a
+}
+
+type asyncWriter struct {
+	w      io.Writer
+	ch     chan []byte
+	stop   chan struct{}
+	done   chan struct{}
+	onDrop func(n int)
+
+	closed  int32
+	dropped uint64
+}
+
+// Write implements io.Writer. It never blocks: if the buffer is full, or the
+// writer has been closed, p is dropped rather than sent on ch, so Write can
+// never race with CloseTimeout over closing a channel writers also send on.
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&a.closed) != 0 {
+		n := atomic.AddUint64(&a.dropped, 1)
+		if a.onDrop != nil {
+			a.onDrop(int(n))
+		}
+
+		return len(p), nil
+	}
+
+	buf := make([]byte, len(p))
+	copy(buf, p)
+
+	select {
+	case a.ch <- buf:
+	default:
+		n := atomic.AddUint64(&a.dropped, 1)
+		if a.onDrop != nil {
+			a.onDrop(int(n))
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close implements io.Closer, flushing any buffered records with
+// defaultCloseTimeout.
+func (a *asyncWriter) Close() error {
+	return a.CloseTimeout(defaultCloseTimeout)
+}
+
+// CloseTimeout stops accepting new writes and flushes any already-buffered
+// records to the underlying writer, waiting at most d for the flush to
+// finish. Calling it more than once returns an error rather than closing an
+// already-closed channel.
+func (a *asyncWriter) CloseTimeout(d time.Duration) error {
+	if !atomic.CompareAndSwapInt32(&a.closed, 0, 1) {
+		return fmt.Errorf("diary: async writer already closed")
+	}
+
+	close(a.stop)
+
+	select {
+	case <-a.done:
+		return nil
+	case <-time.After(d):
+		return fmt.Errorf("diary: async writer close timed out after %s", d)
+	}
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(dropReportInterval)
+	defer ticker.Stop()
+
+	var reported uint64
+
+	for {
+		select {
+		case buf := <-a.ch:
+			a.w.Write(buf)
+		case <-ticker.C:
+			reported = a.reportDrops(reported)
+		case <-a.stop:
+			a.drain(reported)
+			return
+		}
+	}
+}
+
+// drain flushes whatever is already buffered in ch, without blocking on new
+// sends, once a stop signal has been received.
+func (a *asyncWriter) drain(reported uint64) {
+	for {
+		select {
+		case buf := <-a.ch:
+			a.w.Write(buf)
+		default:
+			a.reportDrops(reported)
+			return
+		}
+	}
+}
+
+// reportDrops writes a synthetic log line for any drops since reported,
+// returning the new reported count.
+func (a *asyncWriter) reportDrops(reported uint64) uint64 {
+	current := atomic.LoadUint64(&a.dropped)
+	if current == reported {
+		return reported
+	}
+
+	line := fmt.Sprintf(`{"lvl":"error","message":"async writer dropped %d records"}`+"\n", current-reported)
+	a.w.Write([]byte(line))
+
+	return current
+}
@@ -0,0 +1,98 @@
+package diary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ANSI color codes used by TerminalFormatter, one per level.
+const (
+	ansiReset = "\033[0m"
+	ansiRed   = "\033[31m"
+	ansiCyan  = "\033[36m"
+	ansiGray  = "\033[90m"
+)
+
+var terminalLevelColors = map[Level]string{
+	LevelFatal: ansiRed,
+	LevelError: ansiRed,
+	LevelInfo:  ansiCyan,
+	LevelDebug: ansiGray,
+}
+
+// skippedConsoleKeys are the default keys already rendered as part of the
+// "LVL[timestamp] message" prefix, and so are excluded from the trailing
+// key=value pairs. Loggers using SetTimeKey/SetLevelKey/SetMessageKey/
+// SetCallerKey to rename these fields will see them duplicated in the
+// trailing pairs, since TerminalFormatter has no way to know the renamed
+// keys.
+var skippedConsoleKeys = map[string]struct{}{
+	DefaultTimeKey:    {},
+	DefaultLevelKey:   {},
+	DefaultMessageKey: {},
+	DefaultCallerKey:  {},
+}
+
+// TerminalFormatter renders a record as a single human-readable line:
+//
+//	LVL[2006-01-02T15:04:05Z07:00] message key=value key2=value2
+//
+// Output is colorized per level when the destination writer is a terminal,
+// and left plain otherwise, such as when it is redirected to a file or
+// pipe.
+type TerminalFormatter struct {
+	// DisableColor forces plain output even when writing to a terminal.
+	DisableColor bool
+}
+
+// Format implements Formatter.
+func (t TerminalFormatter) Format(w io.Writer, level Level, ts time.Time, _ Call, ctx map[string]interface{}) error {
+	msg, _ := ctx[DefaultMessageKey].(string)
+
+	var buf bytes.Buffer
+
+	color, ok := terminalLevelColors[level]
+	useColor := ok && !t.DisableColor && isTerminal(w)
+
+	if useColor {
+		buf.WriteString(color)
+	}
+	buf.WriteString(strings.ToUpper(level.String()))
+	if useColor {
+		buf.WriteString(ansiReset)
+	}
+
+	fmt.Fprintf(&buf, "[%s] %s", ts.Format(time.RFC3339), msg)
+
+	var pairs bytes.Buffer
+	writeLogfmtPairs(&pairs, ctx, skippedConsoleKeys)
+	if pairs.Len() > 0 {
+		buf.WriteByte(' ')
+		buf.Write(pairs.Bytes())
+	}
+
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// isTerminal reports whether w is a terminal, for deciding whether to
+// colorize TerminalFormatter output.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
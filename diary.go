@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"sync/atomic"
 	"time"
 )
 
@@ -48,6 +49,12 @@ type (
 		messageKey string
 		callerKey  string
 		callerSkip int
+		filters    []*filterConfig
+		formatter  Formatter
+		hooks      []Hook
+		hookRunner *asyncHookRunner
+		hookErrFn  func(Hook, error)
+		hookErrors uint64
 	}
 
 	// A Value generates a log value. It represents a dynamic value which is re-evaluated with each log event.
@@ -93,6 +100,16 @@ func SetWriter(w io.Writer) func(*Logger) error {
 	}
 }
 
+// SetFormatter creates a function that sets the Formatter used to render
+// records. Generally, used when create a new logger. The default is
+// JSONFormatter, which reproduces diary's original output.
+func SetFormatter(f Formatter) func(*Logger) error {
+	return func(l *Logger) error {
+		l.formatter = f
+		return nil
+	}
+}
+
 // SetTimeKey creates a funtion that sets the time key. Generally, used when create a new logger.
 func SetTimeKey(key string) func(*Logger) error {
 	return func(l *Logger) error {
@@ -158,6 +175,7 @@ func New(context Context, options ...OptionsFunc) (*Logger, error) {
 		messageKey: DefaultMessageKey,
 		callerKey:  DefaultCallerKey,
 		callerSkip: DefaultCallerSkip,
+		formatter:  JSONFormatter{},
 	}
 
 	if err := l.doOptions(options); err != nil {
@@ -178,6 +196,10 @@ func (l *Logger) New(context Context, options ...OptionsFunc) (*Logger, error) {
 		levelKey:   l.levelKey,
 		messageKey: l.messageKey,
 		callerSkip: l.callerSkip,
+		filters:    append([]*filterConfig{}, l.filters...),
+		formatter:  l.formatter,
+		hooks:      append([]Hook{}, l.hooks...),
+		hookErrFn:  l.hookErrFn,
 	}
 
 	ctx := make(Context)
@@ -220,11 +242,63 @@ func (l *Logger) Debug(msg string, context ...Context) {
 	l.write(LevelDebug, msg, context)
 }
 
+// FatalDepth logs a message at the "fatal" log level, attributing it to the
+// caller depth frames above the caller of FatalDepth, then calls os.Exit.
+// It is intended for wrapper libraries that want the caller info to point
+// at their own caller rather than themselves.
+func (l *Logger) FatalDepth(depth int, msg string, context ...Context) {
+	l.writeDepth(LevelFatal, depth, msg, context)
+	os.Exit(-1)
+}
+
+// ErrorDepth logs a message at the "error" log level, attributing it to the
+// caller depth frames above the caller of ErrorDepth.
+func (l *Logger) ErrorDepth(depth int, msg string, context ...Context) {
+	l.writeDepth(LevelError, depth, msg, context)
+}
+
+// InfoDepth logs a message at the "info" log level, attributing it to the
+// caller depth frames above the caller of InfoDepth.
+func (l *Logger) InfoDepth(depth int, msg string, context ...Context) {
+	l.writeDepth(LevelInfo, depth, msg, context)
+}
+
+// DebugDepth logs a message at the "debug" log level, attributing it to the
+// caller depth frames above the caller of DebugDepth.
+func (l *Logger) DebugDepth(depth int, msg string, context ...Context) {
+	l.writeDepth(LevelDebug, depth, msg, context)
+}
+
 func (l *Logger) write(level Level, msg string, context []Context) {
 	if level > l.level {
 		return
 	}
 
+	l.writeCaller(level, caller(l.callerSkip), msg, context)
+}
+
+func (l *Logger) writeDepth(level Level, depth int, msg string, context []Context) {
+	if level > l.level {
+		return
+	}
+
+	l.writeCaller(level, caller(l.callerSkip+depth), msg, context)
+}
+
+// WriteCaller logs a message at the given level using call as the caller
+// information instead of capturing one from the current goroutine's stack.
+// It is exported for adapters, such as diary/slogdiary, that already have
+// caller information from another logging API and would otherwise have to
+// pay for a second, redundant stack walk.
+func (l *Logger) WriteCaller(level Level, call Call, msg string, context ...Context) {
+	if level > l.level {
+		return
+	}
+
+	l.writeCaller(level, call, msg, context)
+}
+
+func (l *Logger) writeCaller(level Level, call Call, msg string, context []Context) {
 	record := make(map[string]interface{}, 8)
 
 	for k, v := range l.context {
@@ -237,18 +311,62 @@ func (l *Logger) write(level Level, msg string, context []Context) {
 		}
 	}
 
-	record[l.timeKey] = time.Now().Format(time.RFC3339Nano)
+	for _, f := range l.filters {
+		if !f.apply(level, record) {
+			return
+		}
+	}
+
+	ts := time.Now()
+	record[l.timeKey] = ts.Format(time.RFC3339Nano)
 	record[l.messageKey] = msg
-	record[l.levelKey] = l.level.String()
-	record[l.callerKey] = caller(l.callerSkip)
+	record[l.levelKey] = level.String()
+	record[l.callerKey] = call
+
+	if len(l.hooks) > 0 {
+		if l.hookRunner != nil {
+			l.hookRunner.submit(level, record)
+		} else {
+			l.fireHooks(level, record)
+		}
+	}
 
-	if data, err := json.Marshal(record); err == nil {
-		data = append(data, '\n')
-		l.writer.Write(data)
-	} else {
+	if err := l.formatter.Format(l.writer, level, ts, call, record); err != nil {
 		fmt.Println(err)
 	}
+}
+
+// fireHooks runs every hook interested in level against record,
+// synchronously on the calling goroutine. A hook's error never prevents
+// the record from being written or other hooks from running: it is
+// counted, and reported via hookErrFn if one is set.
+func (l *Logger) fireHooks(level Level, record map[string]interface{}) {
+	for _, h := range l.hooks {
+		if !levelEnabled(h.Levels(), level) {
+			continue
+		}
+
+		if err := h.Fire(level, record); err != nil {
+			atomic.AddUint64(&l.hookErrors, 1)
+			if l.hookErrFn != nil {
+				l.hookErrFn(h, err)
+			}
+		}
+	}
+}
 
+func levelEnabled(levels []Level, level Level) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// HookErrorCount returns the number of errors returned by hooks so far.
+func (l *Logger) HookErrorCount() uint64 {
+	return atomic.LoadUint64(&l.hookErrors)
 }
 
 var levelsMap = map[Level]string{
@@ -303,6 +421,34 @@ func Debug(msg string, context ...Context) {
 	defaultLogger.Debug(msg, context...)
 }
 
+// FatalDepth uses the default logger to log a message at the "fatal" log
+// level, attributing it to the caller depth frames above the caller of
+// FatalDepth, then calls os.Exit.
+func FatalDepth(depth int, msg string, context ...Context) {
+	defaultLogger.FatalDepth(depth+1, msg, context...)
+}
+
+// ErrorDepth uses the default logger to log a message at the "error" log
+// level, attributing it to the caller depth frames above the caller of
+// ErrorDepth.
+func ErrorDepth(depth int, msg string, context ...Context) {
+	defaultLogger.ErrorDepth(depth+1, msg, context...)
+}
+
+// InfoDepth uses the default logger to log a message at the "info" log
+// level, attributing it to the caller depth frames above the caller of
+// InfoDepth.
+func InfoDepth(depth int, msg string, context ...Context) {
+	defaultLogger.InfoDepth(depth+1, msg, context...)
+}
+
+// DebugDepth uses the default logger to log a message at the "debug" log
+// level, attributing it to the caller depth frames above the caller of
+// DebugDepth.
+func DebugDepth(depth int, msg string, context ...Context) {
+	defaultLogger.DebugDepth(depth+1, msg, context...)
+}
+
 func (v Value) MarshalJSON() ([]byte, error) {
 	// copied from evaluateLazy in log15
 
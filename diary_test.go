@@ -5,6 +5,8 @@ import (
 	"errors"
 	"io/ioutil"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/bakins/diary"
@@ -153,7 +155,7 @@ func TestError(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, l)
 
-	l.Error("this is the message", errors.New("this is an error"))
+	l.Error("this is the message", diary.Context{"error": errors.New("this is an error").Error()})
 	assert.True(t, strings.Contains(b.String(), `"lvl":"error"`))
 	assert.True(t, strings.Contains(b.String(), `"error":"this is an error"`))
 }
@@ -208,3 +210,254 @@ func TestStruct(t *testing.T) {
 	assert.True(t, strings.Contains(b.String(), `"lvl":"debug"`))
 	assert.True(t, strings.Contains(b.String(), `"struct":{"Foo":false`))
 }
+
+func TestFilterLevel(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	f := diary.NewFilter(l, diary.FilterLevel(diary.LevelInfo))
+	f.Debug("this should be dropped")
+	assert.Equal(t, "", b.String())
+
+	f.Info("this should not be dropped")
+	assert.True(t, strings.Contains(b.String(), `"message":"this should not be dropped"`))
+}
+
+func TestFilterKey(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	f := diary.NewFilter(l, diary.FilterKey("password"))
+	f.Info("login", diary.Context{"password": "hunter2", "user": "bob"})
+	assert.True(t, strings.Contains(b.String(), `"password":"***"`))
+	assert.True(t, strings.Contains(b.String(), `"user":"bob"`))
+}
+
+func TestFilterValue(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	f := diary.NewFilter(l, diary.FilterValue("hunter2"))
+	f.Info("login", diary.Context{"secret": "hunter2"})
+	assert.True(t, strings.Contains(b.String(), `"secret":"***"`))
+}
+
+func TestFilterFunc(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	f := diary.NewFilter(l, diary.FilterFunc(func(lvl diary.Level, ctx diary.Context) bool {
+		return ctx["path"] == "/healthz"
+	}))
+	f.Info("request", diary.Context{"path": "/healthz"})
+	assert.Equal(t, "", b.String())
+
+	f.Info("request", diary.Context{"path": "/users"})
+	assert.True(t, strings.Contains(b.String(), `"path":"/users"`))
+}
+
+func TestFilterChain(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	f := diary.NewFilter(l, diary.FilterKey("password"))
+	f = diary.NewFilter(f, diary.FilterLevel(diary.LevelInfo))
+
+	f.Debug("should be dropped", diary.Context{"password": "hunter2"})
+	assert.Equal(t, "", b.String())
+
+	f.Info("should be redacted", diary.Context{"password": "hunter2"})
+	assert.True(t, strings.Contains(b.String(), `"password":"***"`))
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b), diary.SetFormatter(diary.LogfmtFormatter{}))
+	assert.Nil(t, err)
+	assert.NotNil(t, l)
+
+	l.Info("this is the message", diary.Context{"foo": "bar"})
+	assert.True(t, strings.Contains(b.String(), `foo=bar`))
+	assert.True(t, strings.Contains(b.String(), `message="this is the message"`))
+}
+
+func TestTerminalFormatter(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b), diary.SetFormatter(diary.TerminalFormatter{}))
+	assert.Nil(t, err)
+	assert.NotNil(t, l)
+
+	l.Info("this is the message", diary.Context{"foo": "bar"})
+	assert.True(t, strings.Contains(b.String(), "INFO["))
+	assert.True(t, strings.Contains(b.String(), "this is the message foo=bar"))
+}
+
+func TestVModule(t *testing.T) {
+	var b bytes.Buffer
+	diary.SetWriter(&b)(diary.GetDefaultLogger())
+	defer diary.SetVModule("")
+
+	err := diary.SetVModule("diary=2")
+	assert.Nil(t, err)
+
+	diary.V(1).Info("should be logged")
+	assert.True(t, strings.Contains(b.String(), `"message":"should be logged"`))
+	assert.True(t, strings.Contains(b.String(), `"v":1`))
+
+	b.Reset()
+	diary.V(3).Info("should not be logged")
+	assert.Equal(t, "", b.String())
+}
+
+func TestInfoDepth(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	l.InfoDepth(0, "this is the message")
+	assert.True(t, strings.Contains(b.String(), `"message":"this is the message"`))
+}
+
+func TestAsyncWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := diary.NewAsyncWriter(&b, 10, nil)
+
+	l, err := diary.New(nil, diary.SetWriter(w))
+	assert.Nil(t, err)
+
+	l.Info("this is the message")
+	assert.Nil(t, w.Close())
+	assert.True(t, strings.Contains(b.String(), `"message":"this is the message"`))
+}
+
+func TestAsyncWriterDrop(t *testing.T) {
+	var b bytes.Buffer
+	var dropped int32
+	w := diary.NewAsyncWriter(&b, 1, func(n int) { atomic.StoreInt32(&dropped, int32(n)) })
+
+	for i := 0; i < 50; i++ {
+		_, err := w.Write([]byte("x"))
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, w.Close())
+	assert.True(t, atomic.LoadInt32(&dropped) > 0)
+}
+
+func TestAsyncWriterCloseRace(t *testing.T) {
+	w := diary.NewAsyncWriter(ioutil.Discard, 10, nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			w.Write([]byte("x"))
+		}
+	}()
+
+	assert.Nil(t, w.Close())
+	wg.Wait()
+
+	assert.NotNil(t, w.Close())
+}
+
+type recordingHook struct {
+	fired []diary.Level
+}
+
+func (h *recordingHook) Levels() []diary.Level {
+	return []diary.Level{diary.LevelError, diary.LevelFatal}
+}
+
+func (h *recordingHook) Fire(level diary.Level, record map[string]interface{}) error {
+	h.fired = append(h.fired, level)
+	return nil
+}
+
+func TestHookFires(t *testing.T) {
+	var b bytes.Buffer
+	hook := &recordingHook{}
+	l, err := diary.New(nil, diary.SetWriter(&b), diary.AddHook(hook))
+	assert.Nil(t, err)
+
+	l.Debug("not interesting")
+	l.Error("oh no")
+
+	assert.Equal(t, 1, len(hook.fired))
+	assert.Equal(t, diary.Level(diary.LevelError), hook.fired[0])
+}
+
+type erroringHook struct{}
+
+func (erroringHook) Levels() []diary.Level { return []diary.Level{diary.LevelError} }
+func (erroringHook) Fire(diary.Level, map[string]interface{}) error {
+	return errors.New("hook failed")
+}
+
+func TestHookErrorHandler(t *testing.T) {
+	var b bytes.Buffer
+	var caught error
+	l, err := diary.New(nil, diary.SetWriter(&b), diary.AddHook(erroringHook{}),
+		diary.SetHookErrorHandler(func(h diary.Hook, err error) { caught = err }))
+	assert.Nil(t, err)
+
+	l.Error("oh no")
+
+	assert.NotNil(t, caught)
+	assert.Equal(t, uint64(1), l.HookErrorCount())
+	assert.True(t, strings.Contains(b.String(), `"message":"oh no"`))
+}
+
+func TestPrometheusHook(t *testing.T) {
+	var b bytes.Buffer
+	hook := diary.NewPrometheusHook()
+	l, err := diary.New(nil, diary.SetWriter(&b), diary.AddHook(hook))
+	assert.Nil(t, err)
+
+	l.Info("one")
+	l.Info("two")
+	l.Error("three")
+
+	assert.Equal(t, uint64(2), hook.Count(diary.LevelInfo))
+	assert.Equal(t, uint64(1), hook.Count(diary.LevelError))
+}
+
+func TestHooksAsync(t *testing.T) {
+	hook := diary.NewPrometheusHook()
+	l, err := diary.New(nil, diary.SetWriter(ioutil.Discard), diary.AddHook(hook), diary.SetHooksAsync(10))
+	assert.Nil(t, err)
+
+	l.Info("one")
+	l.Error("two")
+
+	assert.Nil(t, l.CloseHooks())
+
+	assert.Equal(t, uint64(1), hook.Count(diary.LevelInfo))
+	assert.Equal(t, uint64(1), hook.Count(diary.LevelError))
+}
+
+func TestHooksAsyncCloseRace(t *testing.T) {
+	hook := diary.NewPrometheusHook()
+	l, err := diary.New(nil, diary.SetWriter(ioutil.Discard), diary.AddHook(hook), diary.SetHooksAsync(10))
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			l.Info("spam")
+		}
+	}()
+
+	assert.Nil(t, l.CloseHooks())
+	wg.Wait()
+
+	assert.NotNil(t, l.CloseHooks())
+}
@@ -0,0 +1,109 @@
+package diary
+
+// redactedValue replaces any filtered value in the emitted record, so the
+// record's schema stays stable rather than dropping the key entirely.
+const redactedValue = "***"
+
+type (
+	// FilterOption configures a filter added by NewFilter.
+	FilterOption func(*filterConfig)
+
+	filterConfig struct {
+		level    Level
+		hasLevel bool
+		keys     map[string]struct{}
+		values   map[string]struct{}
+		fn       func(Level, Context) bool
+	}
+)
+
+// FilterLevel drops records more verbose than lvl.
+func FilterLevel(lvl Level) FilterOption {
+	return func(c *filterConfig) {
+		c.level = lvl
+		c.hasLevel = true
+	}
+}
+
+// FilterKey redacts the value of any of the given context keys to "***"
+// rather than dropping the record. Useful for secret or PII redaction where
+// the field should still be present, just not readable.
+func FilterKey(keys ...string) FilterOption {
+	return func(c *filterConfig) {
+		for _, k := range keys {
+			c.keys[k] = struct{}{}
+		}
+	}
+}
+
+// FilterValue redacts any context value matching one of vals to "***",
+// regardless of which key it was logged under.
+func FilterValue(vals ...string) FilterOption {
+	return func(c *filterConfig) {
+		for _, v := range vals {
+			c.values[v] = struct{}{}
+		}
+	}
+}
+
+// FilterFunc drops a record entirely when fn returns true. It is given the
+// level the record is being logged at and the merged context that will be
+// written, so it can implement arbitrary sampling or suppression logic.
+func FilterFunc(fn func(Level, Context) bool) FilterOption {
+	return func(c *filterConfig) {
+		c.fn = fn
+	}
+}
+
+// NewFilter returns a copy of l that applies the given filters to every
+// record before it is written. Filters run in the order given and compose:
+// wrapping the result in another call to NewFilter returns another copy
+// that keeps the earlier filters and appends the new ones after them, so
+// chained filters inspect and redact the same record
+// rather than each allocating and re-marshaling their own copy.
+func NewFilter(l *Logger, opts ...FilterOption) *Logger {
+	cfg := &filterConfig{
+		keys:   make(map[string]struct{}),
+		values: make(map[string]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	n, err := l.New(nil)
+	if err != nil {
+		n = l
+	}
+
+	n.filters = append(n.filters, cfg)
+
+	return n
+}
+
+// apply reports whether record should still be written after this filter
+// runs, redacting any matched keys or values in place.
+func (c *filterConfig) apply(level Level, ctx Context) bool {
+	if c.hasLevel && level > c.level {
+		return false
+	}
+
+	if c.fn != nil && c.fn(level, ctx) {
+		return false
+	}
+
+	for k, v := range ctx {
+		if _, ok := c.keys[k]; ok {
+			ctx[k] = redactedValue
+			continue
+		}
+
+		if s, ok := v.(string); ok {
+			if _, ok := c.values[s]; ok {
+				ctx[k] = redactedValue
+			}
+		}
+	}
+
+	return true
+}
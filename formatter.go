@@ -0,0 +1,34 @@
+package diary
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Formatter renders a single record to w. level, ts, and caller are the
+// level, timestamp, and caller of the record as typed values, in addition
+// to already being present in ctx under whichever keys the Logger is
+// configured to use (see SetTimeKey, SetLevelKey, SetMessageKey, and
+// SetCallerKey). Formatters that want typed access - to color-code by
+// level, or control the time layout, without depending on those key names
+// can use the typed arguments instead of looking them up in ctx.
+type Formatter interface {
+	Format(w io.Writer, level Level, ts time.Time, caller Call, ctx map[string]interface{}) error
+}
+
+// JSONFormatter renders a record as a single line of JSON. It is the
+// default Formatter, and reproduces diary's original output.
+type JSONFormatter struct{}
+
+// Format implements Formatter.
+func (JSONFormatter) Format(w io.Writer, _ Level, _ time.Time, _ Call, ctx map[string]interface{}) error {
+	data, err := json.Marshal(ctx)
+	if err != nil {
+		return err
+	}
+
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,176 @@
+package diary
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Hook observes records as they are logged, independent of where they are
+// written. Fire is called, synchronously by default, for every record
+// whose level is included in Levels.
+type Hook interface {
+	// Fire is called with the level and fully-assembled record for every
+	// record at a level returned by Levels. An error return is counted and
+	// reported via SetHookErrorHandler, if set, but never prevents the
+	// record from being written or other hooks from running.
+	Fire(level Level, record map[string]interface{}) error
+
+	// Levels returns the levels this hook wants to see.
+	Levels() []Level
+}
+
+// SetHooks replaces the Logger's hooks.
+func SetHooks(hooks ...Hook) func(*Logger) error {
+	return func(l *Logger) error {
+		l.hooks = append([]Hook{}, hooks...)
+		return nil
+	}
+}
+
+// AddHook adds h to the Logger's existing hooks.
+func AddHook(h Hook) func(*Logger) error {
+	return func(l *Logger) error {
+		l.hooks = append(l.hooks, h)
+		return nil
+	}
+}
+
+// SetHookErrorHandler sets fn to be called whenever a hook's Fire returns
+// an error, in addition to it being counted in HookErrorCount.
+func SetHookErrorHandler(fn func(Hook, error)) func(*Logger) error {
+	return func(l *Logger) error {
+		l.hookErrFn = fn
+		return nil
+	}
+}
+
+// SetHooksAsync runs hooks on a background goroutine instead of
+// synchronously before each record is written, reusing the same
+// non-blocking, drop-on-full approach as NewAsyncWriter: if bufSize
+// records are already queued, a new one is dropped rather than blocking
+// the calling goroutine. This option is not inherited by loggers created
+// with Logger.New; each logger that wants async hooks must set it itself.
+//
+// A Logger configured this way should be closed with CloseHooks or
+// CloseHooksTimeout during shutdown, the same way an async writer from
+// NewAsyncWriter is, so queued records are flushed and the background
+// goroutine exits instead of leaking.
+func SetHooksAsync(bufSize int) func(*Logger) error {
+	return func(l *Logger) error {
+		l.hookRunner = newAsyncHookRunner(bufSize, l)
+		return nil
+	}
+}
+
+// CloseHooks stops the Logger's async hook runner, flushing any records
+// already queued for its hooks, waiting at most defaultCloseTimeout. It is
+// a no-op returning nil for a Logger that was not configured with
+// SetHooksAsync.
+func (l *Logger) CloseHooks() error {
+	return l.CloseHooksTimeout(defaultCloseTimeout)
+}
+
+// CloseHooksTimeout is CloseHooks with an explicit flush deadline.
+func (l *Logger) CloseHooksTimeout(d time.Duration) error {
+	if l.hookRunner == nil {
+		return nil
+	}
+
+	return l.hookRunner.closeTimeout(d)
+}
+
+// asyncHookRecord is a record queued for a Logger's hooks to process.
+type asyncHookRecord struct {
+	level  Level
+	record map[string]interface{}
+}
+
+// asyncHookRunner drains queued records into a Logger's hooks on a
+// background goroutine, mirroring asyncWriter: submit never blocks the
+// caller, and closing stops accepting new records without closing the
+// channel submit sends on, so a submit racing a close can never panic.
+type asyncHookRunner struct {
+	ch   chan asyncHookRecord
+	stop chan struct{}
+	done chan struct{}
+
+	closed int32
+}
+
+func newAsyncHookRunner(bufSize int, l *Logger) *asyncHookRunner {
+	r := &asyncHookRunner{
+		ch:   make(chan asyncHookRecord, bufSize),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go r.run(l)
+
+	return r
+}
+
+// submit queues record for the hooks to process, copying it first since
+// the caller will go on to hand the original to its Formatter
+// concurrently. If the queue is full, or the runner has been closed,
+// record is dropped.
+func (r *asyncHookRunner) submit(level Level, record map[string]interface{}) {
+	if atomic.LoadInt32(&r.closed) != 0 {
+		return
+	}
+
+	cp := make(map[string]interface{}, len(record))
+	for k, v := range record {
+		cp[k] = v
+	}
+
+	select {
+	case r.ch <- asyncHookRecord{level: level, record: cp}:
+	default:
+	}
+}
+
+// closeTimeout stops accepting new records and flushes any already-queued
+// ones through the hooks, waiting at most d. Calling it more than once
+// returns an error rather than closing an already-closed channel.
+func (r *asyncHookRunner) closeTimeout(d time.Duration) error {
+	if !atomic.CompareAndSwapInt32(&r.closed, 0, 1) {
+		return fmt.Errorf("diary: async hook runner already closed")
+	}
+
+	close(r.stop)
+
+	select {
+	case <-r.done:
+		return nil
+	case <-time.After(d):
+		return fmt.Errorf("diary: async hook runner close timed out after %s", d)
+	}
+}
+
+func (r *asyncHookRunner) run(l *Logger) {
+	defer close(r.done)
+
+	for {
+		select {
+		case rec := <-r.ch:
+			l.fireHooks(rec.level, rec.record)
+		case <-r.stop:
+			r.drain(l)
+			return
+		}
+	}
+}
+
+// drain flushes whatever is already queued in ch, without blocking on new
+// sends, once a stop signal has been received.
+func (r *asyncHookRunner) drain(l *Logger) {
+	for {
+		select {
+		case rec := <-r.ch:
+			l.fireHooks(rec.level, rec.record)
+		default:
+			return
+		}
+	}
+}
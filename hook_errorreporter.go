@@ -0,0 +1,39 @@
+package diary
+
+import "fmt"
+
+// ErrorReporter is implemented by error-tracking clients, such as a Sentry
+// SDK client, that NewErrorReporterHook can forward records to.
+type ErrorReporter interface {
+	ReportError(msg string, stack string, record map[string]interface{})
+}
+
+// NewErrorReporterHook returns a Hook that forwards Error and Fatal
+// records to reporter, along with a stack trace derived from the record's
+// existing Call caller information.
+func NewErrorReporterHook(reporter ErrorReporter) Hook {
+	return &errorReporterHook{reporter: reporter}
+}
+
+type errorReporterHook struct {
+	reporter ErrorReporter
+}
+
+// Levels implements Hook.
+func (h *errorReporterHook) Levels() []Level {
+	return []Level{LevelFatal, LevelError}
+}
+
+// Fire implements Hook.
+func (h *errorReporterHook) Fire(_ Level, record map[string]interface{}) error {
+	msg, _ := record[DefaultMessageKey].(string)
+
+	var stack string
+	if call, ok := record[DefaultCallerKey].(Call); ok {
+		stack = fmt.Sprintf("%+v", call)
+	}
+
+	h.reporter.ReportError(msg, stack, record)
+
+	return nil
+}
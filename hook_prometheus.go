@@ -0,0 +1,42 @@
+package diary
+
+import "sync/atomic"
+
+// PrometheusHook counts log entries per level. It tracks the counts
+// itself rather than depending on the prometheus client library, so
+// callers can expose them as a diary_log_entries_total{level="..."}
+// counter vector through whatever metrics client they already use.
+type PrometheusHook struct {
+	counts [LevelDebug + 1]uint64
+}
+
+// NewPrometheusHook returns a Hook that counts log entries by level.
+func NewPrometheusHook() *PrometheusHook {
+	return &PrometheusHook{}
+}
+
+// Levels implements Hook.
+func (h *PrometheusHook) Levels() []Level {
+	return []Level{LevelFatal, LevelError, LevelInfo, LevelDebug}
+}
+
+// Fire implements Hook.
+func (h *PrometheusHook) Fire(level Level, _ map[string]interface{}) error {
+	if level < 0 || int(level) >= len(h.counts) {
+		return nil
+	}
+
+	atomic.AddUint64(&h.counts[level], 1)
+
+	return nil
+}
+
+// Count returns the number of entries seen at level, the value a
+// diary_log_entries_total{level="<level>"} counter would report.
+func (h *PrometheusHook) Count(level Level) uint64 {
+	if level < 0 || int(level) >= len(h.counts) {
+		return 0
+	}
+
+	return atomic.LoadUint64(&h.counts[level])
+}
@@ -0,0 +1,89 @@
+package diary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// LogfmtFormatter renders a record as a logfmt-style line: a sequence of
+// space-separated key=value pairs, one line per record. It is suitable for
+// Prometheus-style scraping and other tooling built around the logfmt
+// grammar. Keys are sorted so output is deterministic.
+type LogfmtFormatter struct{}
+
+// Format implements Formatter.
+func (LogfmtFormatter) Format(w io.Writer, _ Level, _ time.Time, _ Call, ctx map[string]interface{}) error {
+	var buf bytes.Buffer
+	writeLogfmtPairs(&buf, ctx, nil)
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeLogfmtPairs writes the sorted key=value pairs of ctx to buf, skipping
+// any key in skip.
+func writeLogfmtPairs(buf *bytes.Buffer, ctx map[string]interface{}, skip map[string]struct{}) {
+	keys := make([]string, 0, len(ctx))
+	for k := range ctx {
+		if _, ok := skip[k]; ok {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(logfmtKey(k))
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(ctx[k]))
+	}
+}
+
+// logfmtKey quotes k if it is not a valid bare logfmt key.
+func logfmtKey(k string) string {
+	if k == "" || needsLogfmtQuoting(k) {
+		return strconv.Quote(k)
+	}
+	return k
+}
+
+// logfmtValue renders v per the logfmt grammar, quoting and escaping it
+// when necessary.
+func logfmtValue(v interface{}) string {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case error:
+		s = t.Error()
+	case fmt.Stringer:
+		s = t.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if s == "" || needsLogfmtQuoting(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// needsLogfmtQuoting reports whether s must be quoted to be a valid logfmt
+// bare key or value: it contains a space or ASCII control character, '=',
+// '"', or '\\'.
+func needsLogfmtQuoting(s string) bool {
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package rotate
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isDiskFull reports whether err indicates the underlying device is out of
+// space.
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
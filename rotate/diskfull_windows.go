@@ -0,0 +1,9 @@
+//go:build windows
+
+package rotate
+
+// isDiskFull always reports false on Windows; ENOSPC detection is not
+// implemented there.
+func isDiskFull(err error) bool {
+	return false
+}
@@ -0,0 +1,328 @@
+// Package rotate provides an io.Writer that rotates the file it writes to
+// by size, age, or a fixed time interval, with optional gzip compression of
+// rotated files. It is meant to be passed to diary.SetWriter.
+package rotate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Common rotation intervals for WithInterval.
+const (
+	// Hourly rotates the file once an hour.
+	Hourly = time.Hour
+	// Daily rotates the file once every 24 hours.
+	Daily = 24 * time.Hour
+)
+
+type (
+	// Option configures a Writer. Generally used when creating one with New.
+	Option func(*Writer) error
+
+	// Writer is an io.Writer that rotates the underlying file according to
+	// its configured options. It is safe for concurrent use by multiple
+	// goroutines.
+	Writer struct {
+		mu sync.Mutex
+
+		filename string
+		file     *os.File
+		size     int64
+
+		maxSize    int64
+		maxAge     time.Duration
+		maxBackups int
+		compress   bool
+		interval   time.Duration
+		nextRotate time.Time
+
+		dropped uint64
+
+		sigStop    chan struct{}
+		sigStopped int32
+	}
+)
+
+// WithMaxSize sets the maximum size, in bytes, a file may reach before it
+// is rotated. Zero, the default, disables size-based rotation.
+func WithMaxSize(n int64) Option {
+	return func(w *Writer) error {
+		w.maxSize = n
+		return nil
+	}
+}
+
+// WithMaxAge sets how long a rotated backup is kept before it is removed
+// during the next rotation's cleanup. Zero, the default, keeps backups
+// forever.
+func WithMaxAge(d time.Duration) Option {
+	return func(w *Writer) error {
+		w.maxAge = d
+		return nil
+	}
+}
+
+// WithMaxBackups sets how many rotated backups are kept; the oldest are
+// removed first. Zero, the default, keeps all of them.
+func WithMaxBackups(n int) Option {
+	return func(w *Writer) error {
+		w.maxBackups = n
+		return nil
+	}
+}
+
+// WithCompress gzip-compresses each file as it is rotated out.
+func WithCompress(compress bool) Option {
+	return func(w *Writer) error {
+		w.compress = compress
+		return nil
+	}
+}
+
+// WithInterval rotates the file every d, independent of and in addition to
+// any size-based rotation. Hourly and Daily cover the common cases.
+func WithInterval(d time.Duration) Option {
+	return func(w *Writer) error {
+		w.interval = d
+		return nil
+	}
+}
+
+// New opens filename for appending, creating it if necessary, and returns a
+// Writer that rotates it according to opts.
+func New(filename string, opts ...Option) (*Writer, error) {
+	w := &Writer{filename: filename}
+
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.openExisting(); err != nil {
+		return nil, err
+	}
+
+	w.watchSIGHUP()
+
+	return w, nil
+}
+
+// DroppedCount returns the number of records dropped so far because a
+// write failed with the disk full.
+func (w *Writer) DroppedCount() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Write implements io.Writer. It rotates the file first if p would put it
+// over the configured size or time interval. A write that fails because
+// the disk is full is dropped, rather than returned as an error or left to
+// block the caller; see DroppedCount.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	if err != nil && isDiskFull(err) {
+		atomic.AddUint64(&w.dropped, 1)
+		return len(p), nil
+	}
+
+	return n, err
+}
+
+// Reopen closes and reopens the file at the configured path. It is
+// intended for use with logrotate: once an external tool has renamed the
+// file out from under the Writer, Reopen creates a fresh file in its
+// place. It is also called automatically on receipt of SIGHUP.
+func (w *Writer) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		w.file.Close()
+	}
+
+	return w.openExisting()
+}
+
+// Close stops watching for SIGHUP and closes the underlying file.
+func (w *Writer) Close() error {
+	w.stopSIGHUP()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+
+	return err
+}
+
+func (w *Writer) openExisting() error {
+	f, err := os.OpenFile(w.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.setNextRotate(time.Now())
+
+	return nil
+}
+
+func (w *Writer) setNextRotate(from time.Time) {
+	if w.interval <= 0 {
+		w.nextRotate = time.Time{}
+		return
+	}
+
+	w.nextRotate = from.Add(w.interval)
+}
+
+func (w *Writer) shouldRotate(n int) bool {
+	if w.maxSize > 0 && w.size+int64(n) > w.maxSize {
+		return true
+	}
+
+	return !w.nextRotate.IsZero() && !time.Now().Before(w.nextRotate)
+}
+
+// rotate closes and renames the current file, then reopens w.filename as a
+// new, empty file. The previous file is fsynced and closed before rotate
+// returns or renames it, so the rename is of a file that is fully flushed
+// to disk. Old backups are then pruned per maxAge and maxBackups.
+func (w *Writer) rotate() error {
+	if w.file != nil {
+		if err := w.file.Sync(); err != nil {
+			return err
+		}
+
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+
+		backup := w.backupName()
+		if err := os.Rename(w.filename, backup); err != nil {
+			return err
+		}
+
+		if w.compress {
+			if err := compressFile(backup); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := w.openExisting(); err != nil {
+		return err
+	}
+
+	w.cleanup()
+
+	return nil
+}
+
+func (w *Writer) backupName() string {
+	ext := filepath.Ext(w.filename)
+	base := strings.TrimSuffix(w.filename, ext)
+
+	return fmt.Sprintf("%s-%s%s", base, time.Now().Format("20060102T150405.000000000"), ext)
+}
+
+func (w *Writer) backupGlob() string {
+	ext := filepath.Ext(w.filename)
+	base := strings.TrimSuffix(w.filename, ext)
+
+	return base + "-*" + ext + "*"
+}
+
+// cleanup removes backups beyond maxBackups and older than maxAge.
+func (w *Writer) cleanup() {
+	if w.maxBackups <= 0 && w.maxAge <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(w.backupGlob())
+	if err != nil {
+		return
+	}
+
+	sort.Strings(matches)
+
+	if w.maxBackups > 0 && len(matches) > w.maxBackups {
+		stale := matches[:len(matches)-w.maxBackups]
+		matches = matches[len(matches)-w.maxBackups:]
+
+		for _, m := range stale {
+			os.Remove(m)
+		}
+	}
+
+	if w.maxAge > 0 {
+		cutoff := time.Now().Add(-w.maxAge)
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				continue
+			}
+
+			if info.ModTime().Before(cutoff) {
+				os.Remove(m)
+			}
+		}
+	}
+}
+
+func compressFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+
+	if _, err := gz.Write(data); err != nil {
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path+".gz", buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,116 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakins/diary/rotate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diary-rotate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "test.log")
+
+	w, err := rotate.New(name)
+	assert.Nil(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("hello\n"))
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(name)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello\n", string(data))
+}
+
+func TestMaxSizeRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diary-rotate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "test.log")
+
+	w, err := rotate.New(name, rotate.WithMaxSize(10))
+	assert.Nil(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("0123456789"))
+	assert.Nil(t, err)
+
+	_, err = w.Write([]byte("more"))
+	assert.Nil(t, err)
+
+	matches, err := filepath.Glob(filepath.Join(dir, "test-*.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(matches))
+
+	data, err := ioutil.ReadFile(name)
+	assert.Nil(t, err)
+	assert.Equal(t, "more", string(data))
+}
+
+func TestMaxBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diary-rotate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "test.log")
+
+	w, err := rotate.New(name, rotate.WithMaxSize(1), rotate.WithMaxBackups(1))
+	assert.Nil(t, err)
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err = w.Write([]byte("x"))
+		assert.Nil(t, err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "test-*.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(matches))
+}
+
+func TestCloseTwice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diary-rotate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "test.log")
+
+	w, err := rotate.New(name)
+	assert.Nil(t, err)
+
+	assert.Nil(t, w.Close())
+	assert.Nil(t, w.Close())
+}
+
+func TestReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diary-rotate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "test.log")
+
+	w, err := rotate.New(name)
+	assert.Nil(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("first\n"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, os.Rename(name, name+".moved"))
+	assert.Nil(t, w.Reopen())
+
+	_, err = w.Write([]byte("second\n"))
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(name)
+	assert.Nil(t, err)
+	assert.Equal(t, "second\n", string(data))
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package rotate
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// watchSIGHUP starts a goroutine that calls Reopen whenever the process
+// receives SIGHUP, for logrotate compatibility, until Close is called.
+func (w *Writer) watchSIGHUP() {
+	w.sigStop = make(chan struct{})
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				w.Reopen()
+			case <-w.sigStop:
+				signal.Stop(ch)
+				return
+			}
+		}
+	}()
+}
+
+// stopSIGHUP stops the goroutine started by watchSIGHUP. It is safe to call
+// more than once: only the first call closes w.sigStop, guarded by
+// sigStopped the same way async.go and hook.go guard their own
+// closed-more-than-once cases.
+func (w *Writer) stopSIGHUP() {
+	if w.sigStop == nil {
+		return
+	}
+
+	if atomic.CompareAndSwapInt32(&w.sigStopped, 0, 1) {
+		close(w.sigStop)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package rotate
+
+// watchSIGHUP is a no-op on Windows, which has no SIGHUP.
+func (w *Writer) watchSIGHUP() {}
+
+func (w *Writer) stopSIGHUP() {}
@@ -0,0 +1,222 @@
+// Package slogdiary adapts between diary and the standard library's
+// log/slog package, so the two can be mixed in a single process: a library
+// that logs via slog can be plugged into a diary sink with NewHandler, and
+// existing diary call sites can be pointed at any slog.Handler with
+// NewLogger.
+package slogdiary
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/bakins/diary"
+)
+
+// LevelFatal is the slog.Level used to represent diary.LevelFatal, which has
+// no equivalent among the standard slog levels.
+const LevelFatal = slog.Level(12)
+
+// Handler is an slog.Handler that writes through a diary.Logger.
+type Handler struct {
+	logger *diary.Logger
+	groups []string
+	attrs  map[string]interface{}
+}
+
+// NewHandler returns an slog.Handler that emits records through l.
+func NewHandler(l *diary.Logger) *Handler {
+	return &Handler{logger: l}
+}
+
+// Enabled always reports true. diary.Logger applies its own level filtering
+// when a record is written, so duplicating that policy here would risk the
+// two disagreeing.
+func (h *Handler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	ctx := make(diary.Context, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		k, v := flattenAttr(a)
+		ctx[k] = v
+		return true
+	})
+
+	merged := deepMerge(h.attrs, nest(h.groups, ctx))
+
+	h.logger.WriteCaller(toDiaryLevel(r.Level), diary.CallerFromPC(r.PC), r.Message, diary.Context(merged))
+
+	return nil
+}
+
+// WithAttrs returns a new Handler with attrs folded into its accumulated
+// attributes, nested under the handler's current groups. It is folded via
+// deepMerge, not a flat map assignment, so attrs added under one group don't
+// clobber attrs already added under that same group by an earlier WithAttrs
+// call.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	ctx := make(map[string]interface{}, len(attrs))
+	for _, a := range attrs {
+		k, v := flattenAttr(a)
+		ctx[k] = v
+	}
+
+	return &Handler{
+		logger: h.logger,
+		groups: h.groups,
+		attrs:  deepMerge(h.attrs, nest(h.groups, ctx)),
+	}
+}
+
+// WithGroup returns a new Handler that nests subsequent attributes and
+// record fields under name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	groups := make([]string, 0, len(h.groups)+1)
+	groups = append(groups, h.groups...)
+	groups = append(groups, name)
+
+	return &Handler{logger: h.logger, groups: groups, attrs: h.attrs}
+}
+
+// flattenAttr resolves a into a diary-friendly key/value pair, recursing
+// into slog groups so they become nested maps.
+func flattenAttr(a slog.Attr) (string, interface{}) {
+	v := a.Value.Resolve()
+
+	if v.Kind() != slog.KindGroup {
+		return a.Key, v.Any()
+	}
+
+	group := v.Group()
+	sub := make(map[string]interface{}, len(group))
+	for _, ga := range group {
+		k, gv := flattenAttr(ga)
+		sub[k] = gv
+	}
+
+	return a.Key, sub
+}
+
+// nest wraps ctx under the given groups, outermost first, as WithGroup
+// would apply them to a slog record.
+func nest(groups []string, ctx map[string]interface{}) map[string]interface{} {
+	m := ctx
+	for i := len(groups) - 1; i >= 0; i-- {
+		m = map[string]interface{}{groups[i]: m}
+	}
+
+	return m
+}
+
+// deepMerge returns a map with src folded into dst: keys unique to either
+// side are kept, and keys present in both are recursively merged when both
+// values are nested maps, so accumulated group attributes from separate
+// WithAttrs calls combine instead of the later call clobbering the earlier
+// one's group entirely.
+func deepMerge(dst, src map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(dst)+len(src))
+	for k, v := range dst {
+		out[k] = v
+	}
+
+	for k, v := range src {
+		if sv, ok := v.(map[string]interface{}); ok {
+			if dv, ok := out[k].(map[string]interface{}); ok {
+				out[k] = deepMerge(dv, sv)
+				continue
+			}
+		}
+
+		out[k] = v
+	}
+
+	return out
+}
+
+// toDiaryLevel maps an slog.Level to the closest diary.Level.
+func toDiaryLevel(l slog.Level) diary.Level {
+	switch {
+	case l < slog.LevelInfo:
+		return diary.LevelDebug
+	case l < slog.LevelError:
+		return diary.LevelInfo
+	case l < LevelFatal:
+		return diary.LevelError
+	default:
+		return diary.LevelFatal
+	}
+}
+
+// fromDiaryLevel maps a diary.Level, as rendered in diary's JSON output, to
+// the closest slog.Level.
+func fromDiaryLevel(s string) slog.Level {
+	lvl, err := diary.LevelFromString(s)
+	if err != nil {
+		return slog.LevelInfo
+	}
+
+	switch lvl {
+	case diary.LevelDebug:
+		return slog.LevelDebug
+	case diary.LevelInfo:
+		return slog.LevelInfo
+	case diary.LevelError:
+		return slog.LevelError
+	case diary.LevelFatal:
+		return LevelFatal
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewLogger returns a diary.Logger that writes through h. It is the inverse
+// of NewHandler: existing diary call sites can be pointed at any
+// slog.Handler, such as one of the standard library's JSON or text
+// handlers, without touching the log/slog API directly.
+func NewLogger(h slog.Handler) *diary.Logger {
+	l, _ := diary.New(nil, diary.SetWriter(&handlerWriter{handler: h}))
+	return l
+}
+
+// handlerWriter adapts an slog.Handler to the io.Writer diary.Logger writes
+// its already-serialized JSON records to. It parses each record back apart
+// so the fields reach the handler as slog attributes instead of one opaque
+// JSON blob.
+type handlerWriter struct {
+	handler slog.Handler
+}
+
+func (w *handlerWriter) Write(p []byte) (int, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(p, &raw); err != nil {
+		// Best effort: diary callers do not expect write errors from a
+		// malformed or unexpected payload to propagate.
+		return len(p), nil
+	}
+
+	msg, _ := raw[diary.DefaultMessageKey].(string)
+	delete(raw, diary.DefaultMessageKey)
+
+	level := slog.LevelInfo
+	if lvl, ok := raw[diary.DefaultLevelKey].(string); ok {
+		level = fromDiaryLevel(lvl)
+	}
+	delete(raw, diary.DefaultLevelKey)
+	delete(raw, diary.DefaultTimeKey)
+	delete(raw, diary.DefaultCallerKey)
+
+	r := slog.NewRecord(time.Now(), level, msg, 0)
+	for k, v := range raw {
+		r.AddAttrs(slog.Any(k, v))
+	}
+
+	if err := w.handler.Handle(context.Background(), r); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
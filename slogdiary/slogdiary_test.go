@@ -0,0 +1,107 @@
+package slogdiary_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bakins/diary"
+	"github.com/bakins/diary/slogdiary"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerBasic(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	logger := slog.New(slogdiary.NewHandler(l))
+	logger.Info("hello", "name", "world")
+
+	var record map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &record))
+	assert.Equal(t, "hello", record[diary.DefaultMessageKey])
+	assert.Equal(t, "world", record["name"])
+}
+
+func TestHandlerCallerIsCallSite(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	logger := slog.New(slogdiary.NewHandler(l))
+	logger.Info("marker line") // the next line records this call's line number.
+	_, _, wantLine, _ := runtime.Caller(0)
+	wantLine--
+
+	var record map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &record))
+
+	caller, _ := record[diary.DefaultCallerKey].(string)
+	assert.True(t, strings.Contains(caller, "slogdiary_test.go:"+strconv.Itoa(wantLine)))
+	assert.True(t, !strings.Contains(caller, "log/slog"))
+}
+
+func TestHandlerWithGroup(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	logger := slog.New(slogdiary.NewHandler(l)).WithGroup("a")
+	logger.Info("hello", "x", 1)
+
+	var record map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &record))
+
+	group, ok := record["a"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), group["x"])
+}
+
+func TestHandlerWithAttrsUnderSameGroupMerge(t *testing.T) {
+	var b bytes.Buffer
+	l, err := diary.New(nil, diary.SetWriter(&b))
+	assert.Nil(t, err)
+
+	logger := slog.New(slogdiary.NewHandler(l)).WithGroup("a").With("x", 1)
+	logger.Info("first", "y", 2)
+
+	var record map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &record))
+
+	group, ok := record["a"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), group["x"])
+	assert.Equal(t, float64(2), group["y"])
+}
+
+func TestNewLoggerRoundTrip(t *testing.T) {
+	var records []map[string]interface{}
+	h := slog.NewJSONHandler(&recordingWriter{records: &records}, nil)
+
+	l := slogdiary.NewLogger(h)
+	l.Info("hello", diary.Context{"name": "world"})
+
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "hello", records[0]["msg"])
+	assert.Equal(t, "world", records[0]["name"])
+}
+
+type recordingWriter struct {
+	records *[]map[string]interface{}
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	var record map[string]interface{}
+	if err := json.Unmarshal(p, &record); err != nil {
+		return 0, err
+	}
+
+	*w.records = append(*w.records, record)
+
+	return len(p), nil
+}
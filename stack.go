@@ -37,6 +37,24 @@ func caller(skip int) Call {
 	return c
 }
 
+// CallerFromPC returns a Call describing the function identified by pc. It
+// is intended for adapters that receive a program counter from another
+// logging API (such as log/slog's Record.PC) and want to render it the same
+// way diary renders its own caller information, without paying for a second
+// runtime.Callers walk.
+//
+// pc is expected to be a raw return address, as runtime.Callers and
+// log/slog's Record.PC produce: such addresses point just past the call
+// instruction, so pc-1 is required to resolve the calling line itself
+// rather than the line after it, the same adjustment caller applies.
+func CallerFromPC(pc uintptr) Call {
+	if pc == 0 {
+		return Call{}
+	}
+	pc--
+	return Call{pc: pc, fn: runtime.FuncForPC(pc)}
+}
+
 // String implements fmt.Stinger. It is equivalent to fmt.Sprintf("%v", c).
 func (c Call) String() string {
 	return fmt.Sprint(c)
@@ -0,0 +1,188 @@
+package diary
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Verbose is returned by V. Its Info method is a no-op unless the
+// requested verbosity level is enabled for the calling file.
+type Verbose struct {
+	enabled bool
+	level   int
+}
+
+type vmoduleRule struct {
+	pattern *regexp.Regexp
+	level   int
+}
+
+var (
+	// vmoduleRules holds the current []vmoduleRule, set atomically by
+	// SetVModule so it can be read on the hot path without locking.
+	vmoduleRules atomic.Value
+
+	// vcache caches the effective verbosity decision for a call site,
+	// keyed by the uintptr program counter of its call to V, so repeat
+	// calls from the same line skip re-matching the vmodule patterns.
+	vcache sync.Map
+)
+
+func init() {
+	vmoduleRules.Store([]vmoduleRule(nil))
+}
+
+// SetVModule sets the per-module verbosity rules, mirroring glog's vmodule
+// flag. spec is a comma-separated list of pattern=level entries, for
+// example "foo=2,*/bar/*=1". pattern is matched, using glob semantics ('*'
+// and '?'), against the calling file's base name, its package name (as
+// derived from the calling function's import path, not the on-disk
+// checkout directory), and its full source path, so both short package
+// names and full paths work as patterns. Each pattern must match the whole
+// string, so a path pattern needs a leading '*' to match an absolute path.
+// Later entries win ties.
+func SetVModule(spec string) error {
+	var rules []vmoduleRule
+
+	if spec != "" {
+		for _, entry := range strings.Split(spec, ",") {
+			kv := strings.SplitN(entry, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("diary: invalid vmodule entry %q", entry)
+			}
+
+			level, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return fmt.Errorf("diary: invalid vmodule level in %q: %w", entry, err)
+			}
+
+			pattern, err := globToRegexp(kv[0])
+			if err != nil {
+				return fmt.Errorf("diary: invalid vmodule pattern in %q: %w", entry, err)
+			}
+
+			rules = append(rules, vmoduleRule{pattern: pattern, level: level})
+		}
+	}
+
+	vmoduleRules.Store(rules)
+
+	// Invalidate cached per-call-site decisions; they were made under the
+	// old rules.
+	vcache.Range(func(key, _ interface{}) bool {
+		vcache.Delete(key)
+		return true
+	})
+
+	return nil
+}
+
+// globToRegexp compiles a glob pattern using '*' (any run of characters)
+// and '?' (any single character) into a regexp anchored to match the whole
+// string.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteByte('^')
+
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteByte('$')
+
+	return regexp.Compile(b.String())
+}
+
+// V reports whether logging at the given verbosity level is currently
+// enabled for the calling function's source file, per the rules set with
+// SetVModule. A call site that no vmodule rule matches has an effective
+// verbosity of 0, so only V(0) and lower are enabled there.
+func V(level int) Verbose {
+	pc, file, _, ok := runtime.Caller(1)
+	if !ok {
+		return Verbose{}
+	}
+
+	if cached, ok := vcache.Load(pc); ok {
+		return Verbose{enabled: level <= cached.(int), level: level}
+	}
+
+	effective := effectiveVerbosity(pc, file)
+	vcache.Store(pc, effective)
+
+	return Verbose{enabled: level <= effective, level: level}
+}
+
+// effectiveVerbosity returns the configured verbosity for the call site at
+// pc in file, or 0 if no vmodule rule matches it.
+func effectiveVerbosity(pc uintptr, file string) int {
+	rules, _ := vmoduleRules.Load().([]vmoduleRule)
+	if len(rules) == 0 {
+		return 0
+	}
+
+	base := filepath.Base(file)
+	pkg := packageName(pc)
+
+	level := 0
+	for _, r := range rules {
+		if r.pattern.MatchString(base) || (pkg != "" && r.pattern.MatchString(pkg)) || r.pattern.MatchString(file) {
+			level = r.level
+		}
+	}
+
+	return level
+}
+
+// packageName returns the short package name (the last import path element,
+// e.g. "diary" for "github.com/bakins/diary.V") of the function at pc, or
+// "" if pc can't be resolved. Unlike deriving it from the file's containing
+// directory, this is stable across any checkout layout: GOPATH, module
+// cache directories such as ".../diary@v1.2.3/", vendoring, and so on.
+func packageName(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+
+	if i := strings.Index(name, "."); i != -1 {
+		name = name[:i]
+	}
+
+	// An external test file (e.g. diary_test.go declaring "package
+	// diary_test") compiles into a synthetic "<pkg>_test" package; strip
+	// that toolchain-added suffix so vmodule patterns still match the
+	// package being tested.
+	name = strings.TrimSuffix(name, "_test")
+
+	return name
+}
+
+// Info logs msg at the "debug" log level, with an added "v" field set to
+// the requested verbosity level, using the default logger. It is a no-op
+// if v is not enabled.
+func (v Verbose) Info(msg string, context ...Context) {
+	if !v.enabled {
+		return
+	}
+
+	defaultLogger.writeDepth(LevelDebug, 0, msg, append([]Context{{"v": v.level}}, context...))
+}